@@ -0,0 +1,155 @@
+// Package openapi compares the OpenAPI V2 and OpenAPI V3 documents served by the same API
+// server, so e2e tests can assert the two stay in sync for a given group version.
+package openapi
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/kube-openapi/pkg/spec3"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+// Mismatch describes a path or schema that is present in the V2 document for a group version
+// but missing, or structurally different, in the V3 document.
+type Mismatch struct {
+	Path   string
+	Reason string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %s", m.Path, m.Reason)
+}
+
+// CompareV2ToV3 checks that every path served under v2Prefix (the subset of v2.Paths that
+// belongs to the group version under test) has a counterpart in v3. For the GET, POST and PUT
+// operations of each such path it also checks that the operation exists in v3 and that the
+// definition referenced by its 200 response has a V3 component schema with the same required
+// fields and the same types for the properties both schemas share. Missing paths, operations,
+// component schemas and required fields, and property type changes, are reported as
+// mismatches. V3-only additions (new paths, new components, new properties) are tolerated, and
+// so is an optional V2 property that the V3 schema lacks; other operations and other
+// references are not compared.
+func CompareV2ToV3(v2 *spec.Swagger, v2Prefix string, v3 *spec3.OpenAPI) []Mismatch {
+	var mismatches []Mismatch
+	if v2 == nil || v2.Paths == nil || v3 == nil || v3.Paths == nil {
+		return mismatches
+	}
+
+	for path, v2Item := range v2.Paths.Paths {
+		if !strings.HasPrefix(path, v2Prefix) {
+			continue
+		}
+		v3Item, ok := v3.Paths.Paths[path]
+		if !ok {
+			mismatches = append(mismatches, Mismatch{Path: path, Reason: "present in V2 but missing from V3"})
+			continue
+		}
+		mismatches = append(mismatches, compareOperationRefs(path, "GET", v2Item.Get, opV3Operation(v3Item.Get), v2, v3)...)
+		mismatches = append(mismatches, compareOperationRefs(path, "POST", v2Item.Post, opV3Operation(v3Item.Post), v2, v3)...)
+		mismatches = append(mismatches, compareOperationRefs(path, "PUT", v2Item.Put, opV3Operation(v3Item.Put), v2, v3)...)
+	}
+	return mismatches
+}
+
+func opV3Operation(op *spec3.Operation) *spec3.Operation {
+	return op
+}
+
+func compareOperationRefs(path, method string, v2Op *spec.Operation, v3Op *spec3.Operation, v2 *spec.Swagger, v3 *spec3.OpenAPI) []Mismatch {
+	if v2Op == nil {
+		return nil
+	}
+	if v3Op == nil {
+		return []Mismatch{{Path: path, Reason: fmt.Sprintf("%s present in V2 but missing from V3", method)}}
+	}
+
+	v2Ref, ok := responseRef(v2Op)
+	if !ok {
+		return nil
+	}
+	v2Def, ok := v2.Definitions[normalizeV2Ref(v2Ref)]
+	if !ok {
+		return nil
+	}
+
+	v3Schema := findComponentSchema(v3, normalizeV2Ref(v2Ref))
+	if v3Schema == nil {
+		return []Mismatch{{Path: path, Reason: fmt.Sprintf("%s %s has no V3 components/schemas counterpart", method, v2Ref)}}
+	}
+
+	var mismatches []Mismatch
+	for _, name := range v2Def.Required {
+		if !containsString(v3Schema.Required, name) {
+			mismatches = append(mismatches, Mismatch{Path: path, Reason: fmt.Sprintf("required field %q missing from V3 schema", name)})
+		}
+	}
+
+	for name, v2Prop := range v2Def.Properties {
+		v3Prop, ok := v3Schema.Properties[name]
+		if !ok {
+			// A V3-only schema may still be narrower than its V2 counterpart during
+			// rollout; a missing property isn't itself a mismatch, only a type change is.
+			continue
+		}
+		if !sameTypes(v2Prop.Type, v3Prop.Type) {
+			mismatches = append(mismatches, Mismatch{Path: path, Reason: fmt.Sprintf("property %q has type %v in V2 but %v in V3", name, v2Prop.Type, v3Prop.Type)})
+		}
+	}
+	return mismatches
+}
+
+// sameTypes reports whether two JSON Schema "type" value lists describe the same set of
+// types, ignoring order.
+func sameTypes(a, b spec.StringOrArray) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, t := range a {
+		if !containsString(b, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func responseRef(op *spec.Operation) (string, bool) {
+	if op == nil || op.Responses == nil {
+		return "", false
+	}
+	ok200, ok := op.Responses.StatusCodeResponses[200]
+	if !ok || ok200.Schema == nil || ok200.Schema.Ref.String() == "" {
+		return "", false
+	}
+	return ok200.Schema.Ref.String(), true
+}
+
+// normalizeV2Ref strips the "#/definitions/" prefix from a V2 $ref so it can be compared
+// against a V3 components/schemas key.
+func normalizeV2Ref(ref string) string {
+	return strings.TrimPrefix(ref, "#/definitions/")
+}
+
+func findComponentSchema(v3 *spec3.OpenAPI, name string) *spec.Schema {
+	if v3.Components == nil {
+		return nil
+	}
+	return v3.Components.Schemas[name]
+}
+
+// InfoTitlesConfigured reports whether both documents carry a non-empty info.title, mirroring
+// the upstream split where OpenAPIConfig and OpenAPIV3Config set their titles independently of
+// one another (neither falls back to, or is derived from, the other).
+func InfoTitlesConfigured(v2 *spec.Swagger, v3 *spec3.OpenAPI) bool {
+	return v2 != nil && v2.Info != nil && v2.Info.Title != "" &&
+		v3 != nil && v3.Info != nil && v3.Info.Title != ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
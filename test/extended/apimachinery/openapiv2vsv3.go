@@ -0,0 +1,81 @@
+package apimachinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	g "github.com/onsi/ginkgo/v2"
+	o "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/openapi3"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"github.com/openshift/origin/test/extended/apimachinery/openapi"
+	exutil "github.com/openshift/origin/test/extended/util"
+)
+
+// builtinV2VsV3GroupVersions lists the built-in groups without a k8s.io suffix that this suite
+// compares across V2 and V3, the same allow-list as the upstream vendored OpenAPIV3 RoundTrip
+// test. The core group and the k8s.io-suffixed groups are selected separately by the test
+// below; unlike the upstream test, the core group is included.
+var builtinV2VsV3GroupVersions = map[string]bool{
+	"apps":        true,
+	"autoscaling": true,
+	"batch":       true,
+	"policy":      true,
+}
+
+// v2PathPrefix returns the V2 discovery path prefix for gv, e.g. "/api/v1/" for the core group
+// or "/apis/apps/v1/" for a named group.
+func v2PathPrefix(gv schema.GroupVersion) string {
+	if gv.Group == "" {
+		return fmt.Sprintf("/api/%s/", gv.Version)
+	}
+	return fmt.Sprintf("/apis/%s/%s/", gv.Group, gv.Version)
+}
+
+var _ = g.Describe("[sig-api-machinery] OpenAPI V2 vs V3", func() {
+	defer g.GinkgoRecover()
+	oc := exutil.NewCLIWithPodSecurityLevel("openapi-v2-vs-v3", admissionapi.LevelBaseline)
+
+	// Release : 4.18
+	// Testname: OpenAPI V2 and V3 consistency
+	// Description: For every built-in group version, the paths and schemas published by V2
+	// discovery MUST also be present in V3 discovery, with structurally equivalent required
+	// fields and property types. V3-only additions are tolerated; a V2-only path, schema or
+	// required field indicates a group version whose V3 wiring is broken. The V2 and V3 documents MUST also
+	// each carry their own non-empty info.title, since OpenAPIConfig and OpenAPIV3Config are
+	// configured independently.
+	g.It("should publish OpenAPI V3 documents that are a superset of the corresponding OpenAPI V2 documents", func(ctx context.Context) {
+		raw, err := oc.AdminKubeClient().Discovery().RESTClient().Get().AbsPath("/openapi/v2").DoRaw(ctx)
+		o.Expect(err).NotTo(o.HaveOccurred())
+		var v2Swagger spec.Swagger
+		o.Expect(json.Unmarshal(raw, &v2Swagger)).To(o.Succeed())
+
+		c := openapi3.NewRoot(oc.AdminKubeClient().Discovery().OpenAPIV3())
+		gvs, err := c.GroupVersions()
+		o.Expect(err).NotTo(o.HaveOccurred())
+
+		var builtin []schema.GroupVersion
+		for _, gv := range gvs {
+			if gv.Group == "" || strings.HasSuffix(gv.Group, "k8s.io") || builtinV2VsV3GroupVersions[gv.Group] {
+				builtin = append(builtin, gv)
+			}
+		}
+		o.Expect(builtin).NotTo(o.BeEmpty())
+
+		for _, gv := range builtin {
+			v3Spec, err := c.GVSpec(gv)
+			o.Expect(err).NotTo(o.HaveOccurred(), "fetching V3 spec for %s", gv)
+
+			mismatches := openapi.CompareV2ToV3(&v2Swagger, v2PathPrefix(gv), v3Spec)
+			o.Expect(mismatches).To(o.BeEmpty(), "OpenAPI V2/V3 mismatches for %s: %v", gv, mismatches)
+
+			o.Expect(openapi.InfoTitlesConfigured(&v2Swagger, v3Spec)).To(o.BeTrue(), "expected V2 and V3 documents for %s to each carry their own non-empty info.title", gv)
+		}
+	})
+})
@@ -0,0 +1,410 @@
+package apimachinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	g "github.com/onsi/ginkgo/v2"
+	o "github.com/onsi/gomega"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apiextensions-apiserver/test/integration/fixtures"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/openapi3"
+	"k8s.io/client-go/rest"
+	"k8s.io/kube-openapi/pkg/spec3"
+	validationspec "k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kubernetes/test/e2e/framework"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	exutil "github.com/openshift/origin/test/extended/util"
+)
+
+// carryGroupOverlap describes one of OpenShift's carried groups that are served both by a
+// CRD-backed controller (e.g. a CRD installed by a cluster operator) and by openshift-apiserver
+// as an aggregated API. The OpenAPI V3 document for the group must present the union of both.
+type carryGroupOverlap struct {
+	group            string
+	version          string
+	crdKind          string
+	crdPlural        string
+	aggregatedKind   string
+	aggregatedPlural string
+}
+
+var carryGroupOverlaps = []carryGroupOverlap{
+	{group: "authorization.openshift.io", version: "v1", crdKind: "RoleBindingRestriction", crdPlural: "rolebindingrestrictions", aggregatedKind: "Role", aggregatedPlural: "roles"},
+	{group: "security.openshift.io", version: "v1", crdKind: "PodSecurityPolicySubjectReview", crdPlural: "podsecuritypolicysubjectreviews", aggregatedKind: "SecurityContextConstraints", aggregatedPlural: "securitycontextconstraints"},
+	{group: "quota.openshift.io", version: "v1", crdKind: "AppliedClusterResourceQuota", crdPlural: "appliedclusterresourcequotas", aggregatedKind: "ClusterResourceQuota", aggregatedPlural: "clusterresourcequotas"},
+}
+
+var _ = g.Describe("[sig-api-machinery] OpenAPI V3", func() {
+	defer g.GinkgoRecover()
+	oc := exutil.NewCLIWithPodSecurityLevel("openapi-v3", admissionapi.LevelBaseline)
+
+	// Release : 4.17
+	// Testname: OpenAPI V3 carry group merge
+	// Description: OpenShift carries several groups (authorization.openshift.io,
+	// security.openshift.io, quota.openshift.io, ...) that are served simultaneously by
+	// openshift-apiserver and by a CRD installed for a subset of their kinds. The merged
+	// OpenAPI V3 document for the group MUST contain paths and schemas from both sources,
+	// and MUST keep serving the CRD-provided paths even when openshift-apiserver is
+	// temporarily unavailable.
+	g.It("should merge OpenAPI V3 specs from CRDs and aggregated API servers for overlapping OpenShift groups [apigroup:apiextensions.k8s.io] [Serial][Disruptive]", func() {
+		config, err := framework.LoadConfig()
+		o.Expect(err).NotTo(o.HaveOccurred())
+		apiExtensionClient, err := apiextensionclientset.NewForConfig(config)
+		o.Expect(err).NotTo(o.HaveOccurred())
+		dynamicClient, err := dynamic.NewForConfig(config)
+		o.Expect(err).NotTo(o.HaveOccurred())
+
+		c := openapi3.NewRoot(oc.AdminKubeClient().Discovery().OpenAPIV3())
+
+		for _, overlap := range carryGroupOverlaps {
+			overlap := overlap
+			g.By(fmt.Sprintf("merging CRD and aggregated paths for %s/%s", overlap.group, overlap.version))
+
+			crd := fixtures.NewRandomNameV1CustomResourceDefinition(apiextensionsv1.ClusterScoped)
+			crd.Spec.Group = overlap.group
+			crd.Spec.Versions[0].Name = overlap.version
+			crd.Spec.Names.Kind = overlap.crdKind
+			crd.Spec.Names.Plural = overlap.crdPlural
+			crd.Spec.Names.Singular = strings.ToLower(overlap.crdKind)
+
+			_, err = fixtures.CreateNewV1CustomResourceDefinition(crd, apiExtensionClient, dynamicClient)
+			o.Expect(err).NotTo(o.HaveOccurred())
+
+			gv := schema.GroupVersion{Group: overlap.group, Version: overlap.version}
+			crdPath := fmt.Sprintf("/apis/%s/%s/%s", overlap.group, overlap.version, overlap.crdPlural)
+			aggregatedPath := fmt.Sprintf("/apis/%s/%s/%s", overlap.group, overlap.version, overlap.aggregatedPlural)
+
+			var merged *spec3.OpenAPI
+			err = wait.Poll(time.Second, wait.ForeverTestTimeout, func() (bool, error) {
+				merged, err = c.GVSpec(gv)
+				if err != nil {
+					return false, nil
+				}
+				_, hasCRDPath := merged.Paths.Paths[crdPath]
+				_, hasAggregatedPath := merged.Paths.Paths[aggregatedPath]
+				return hasCRDPath && hasAggregatedPath, nil
+			})
+			o.Expect(err).NotTo(o.HaveOccurred(), "expected merged OpenAPI V3 document to contain both CRD and aggregated paths for %s/%s", overlap.group, overlap.version)
+
+			crdSchema := findSchemaForKind(merged, overlap.crdKind)
+			aggregatedSchema := findSchemaForKind(merged, overlap.aggregatedKind)
+			o.Expect(crdSchema).NotTo(o.BeNil(), "expected components/schemas to contain the CRD-provided %s schema", overlap.crdKind)
+			o.Expect(aggregatedSchema).NotTo(o.BeNil(), "expected components/schemas to contain the aggregated %s schema, i.e. the CRD merge must not overwrite it", overlap.aggregatedKind)
+
+			specMarshalled, err := json.Marshal(merged)
+			o.Expect(err).NotTo(o.HaveOccurred())
+			var roundTripped spec3.OpenAPI
+			o.Expect(json.Unmarshal(specMarshalled, &roundTripped)).To(o.Succeed())
+			if !reflect.DeepEqual(*merged, roundTripped) {
+				diff := cmp.Diff(*merged, roundTripped)
+				framework.Failf("%s", diff)
+			}
+
+			err = fixtures.DeleteV1CustomResourceDefinition(crd, apiExtensionClient)
+			o.Expect(err).NotTo(o.HaveOccurred())
+		}
+
+		g.By("scaling down openshift-apiserver to verify CRD-provided paths remain served")
+		overlap := carryGroupOverlaps[0]
+		crd := fixtures.NewRandomNameV1CustomResourceDefinition(apiextensionsv1.ClusterScoped)
+		crd.Spec.Group = overlap.group
+		crd.Spec.Versions[0].Name = overlap.version
+		crd.Spec.Names.Kind = overlap.crdKind
+		crd.Spec.Names.Plural = overlap.crdPlural
+		crd.Spec.Names.Singular = strings.ToLower(overlap.crdKind)
+		_, err = fixtures.CreateNewV1CustomResourceDefinition(crd, apiExtensionClient, dynamicClient)
+		o.Expect(err).NotTo(o.HaveOccurred())
+		defer func() {
+			err := fixtures.DeleteV1CustomResourceDefinition(crd, apiExtensionClient)
+			o.Expect(err).NotTo(o.HaveOccurred())
+		}()
+
+		gv := schema.GroupVersion{Group: overlap.group, Version: overlap.version}
+		crdPath := fmt.Sprintf("/apis/%s/%s/%s", overlap.group, overlap.version, overlap.crdPlural)
+		err = wait.Poll(time.Second, wait.ForeverTestTimeout, func() (bool, error) {
+			spec, err := c.GVSpec(gv)
+			if err != nil {
+				return false, nil
+			}
+			_, hasCRDPath := spec.Paths.Paths[crdPath]
+			return hasCRDPath, nil
+		})
+		o.Expect(err).NotTo(o.HaveOccurred(), "expected %s/%s to be present before scaling down openshift-apiserver", overlap.group, overlap.version)
+
+		previousReplicas := getOpenShiftAPIServerReplicas(oc)
+		scaleOpenShiftAPIServer(oc, 0)
+		defer scaleOpenShiftAPIServer(oc, previousReplicas)
+
+		err = wait.Poll(time.Second, wait.ForeverTestTimeout, func() (bool, error) {
+			spec, err := c.GVSpec(gv)
+			if err != nil {
+				return false, nil
+			}
+			_, hasCRDPath := spec.Paths.Paths[crdPath]
+			return hasCRDPath, nil
+		})
+		o.Expect(err).NotTo(o.HaveOccurred(), "CRD-provided paths must remain served while openshift-apiserver is unavailable")
+	})
+
+	// Release : 4.17
+	// Testname: OpenAPI V3 behind a path-prefixed proxy
+	// Description: Clients that reach the API server through a reverse proxy adding a path
+	// prefix (as OpenShift's workspace/cluster-scoped proxies do) MUST still be able to
+	// resolve the relative paths returned by OpenAPI V3 discovery against their own
+	// configured prefix, rather than against the apiserver's raw RequestURI.
+	g.It("should resolve OpenAPI V3 relative paths against a client-configured path prefix", func() {
+		config, err := framework.LoadConfig()
+		o.Expect(err).NotTo(o.HaveOccurred())
+
+		proxy, err := newPathPrefixingProxy(config, "/test-endpoint")
+		o.Expect(err).NotTo(o.HaveOccurred())
+		server := httptest.NewServer(proxy)
+		defer server.Close()
+
+		prefixed := rest.CopyConfig(config)
+		prefixed.Host = server.URL + "/test-endpoint"
+
+		prefixedClient, err := kubernetes.NewForConfig(prefixed)
+		o.Expect(err).NotTo(o.HaveOccurred())
+
+		c := openapi3.NewRoot(prefixedClient.Discovery().OpenAPIV3())
+		gvs, err := c.GroupVersions()
+		o.Expect(err).NotTo(o.HaveOccurred())
+		o.Expect(gvs).NotTo(o.BeEmpty())
+
+		// List of built in types that do not contain the k8s.io suffix, mirroring the
+		// upstream vendored OpenAPIV3 RoundTrip test so the full set of built-in group
+		// versions is exercised through the prefix, not just the k8s.io-suffixed ones. The
+		// core group's Group is the empty string, so it has to be special-cased rather than
+		// looked up in builtinGVs.
+		builtinGVs := map[string]bool{
+			"apps":        true,
+			"autoscaling": true,
+			"batch":       true,
+			"policy":      true,
+		}
+
+		for _, gv := range gvs {
+			if gv.Group != "" && !strings.HasSuffix(gv.Group, "k8s.io") && !builtinGVs[gv.Group] {
+				continue
+			}
+			_, err := c.GVSpec(gv)
+			o.Expect(err).NotTo(o.HaveOccurred(), "GVSpec(%s) must succeed through a %q prefixed client", gv, "/test-endpoint")
+		}
+	})
+
+	// Release : 4.18
+	// Testname: OpenAPI V3 CustomResourceDefinition structural schema features
+	// Description: Create a CustomResourceDefinition whose versions declare selectableFields,
+	// x-kubernetes-validations, a map-type list with listMapKeys, and
+	// x-kubernetes-preserve-unknown-fields. The published OpenAPI V3 document MUST carry the
+	// corresponding vendor extensions and field-selector query parameters, these MUST survive
+	// a JSON round trip, and each version's schema MUST remain distinct.
+	g.It("should publish OpenAPI V3 vendor extensions for CustomResourceDefinition selectable fields and structural schema features [apigroup:apiextensions.k8s.io]", func() {
+		config, err := framework.LoadConfig()
+		o.Expect(err).NotTo(o.HaveOccurred())
+		apiExtensionClient, err := apiextensionclientset.NewForConfig(config)
+		o.Expect(err).NotTo(o.HaveOccurred())
+		dynamicClient, err := dynamic.NewForConfig(config)
+		o.Expect(err).NotTo(o.HaveOccurred())
+
+		crd := fixtures.NewRandomNameV1CustomResourceDefinition(apiextensionsv1.ClusterScoped)
+		crd.Spec.Versions = []apiextensionsv1.CustomResourceDefinitionVersion{
+			newStructuralSchemaVersion("v1alpha1", false),
+			newStructuralSchemaVersion("v1beta1", true),
+		}
+
+		_, err = fixtures.CreateNewV1CustomResourceDefinition(crd, apiExtensionClient, dynamicClient)
+		o.Expect(err).NotTo(o.HaveOccurred())
+		defer func() {
+			err := fixtures.DeleteV1CustomResourceDefinition(crd, apiExtensionClient)
+			o.Expect(err).NotTo(o.HaveOccurred())
+		}()
+
+		c := openapi3.NewRoot(oc.AdminKubeClient().Discovery().OpenAPIV3())
+
+		specsByVersion := map[string]*spec3.OpenAPI{}
+		for _, v := range crd.Spec.Versions {
+			gv := schema.GroupVersion{Group: crd.Spec.Group, Version: v.Name}
+			var versionSpec *spec3.OpenAPI
+			err := wait.Poll(time.Second, wait.ForeverTestTimeout, func() (bool, error) {
+				versionSpec, err = c.GVSpec(gv)
+				return err == nil, nil
+			})
+			o.Expect(err).NotTo(o.HaveOccurred())
+			specsByVersion[v.Name] = versionSpec
+		}
+
+		v1alpha1Schema := findSchemaForKind(specsByVersion["v1alpha1"], crd.Spec.Names.Kind)
+		v1beta1Schema := findSchemaForKind(specsByVersion["v1beta1"], crd.Spec.Names.Kind)
+		o.Expect(v1alpha1Schema).NotTo(o.BeNil())
+		o.Expect(v1beta1Schema).NotTo(o.BeNil())
+		o.Expect(v1alpha1Schema).NotTo(o.Equal(v1beta1Schema), "per-version schemas must be distinct")
+
+		specSchema := v1beta1Schema.Properties["spec"]
+		widgetsSchema := specSchema.Properties["widgets"]
+		o.Expect(specSchema.VendorExtensible.Extensions).To(o.HaveKey("x-kubernetes-validations"))
+		o.Expect(widgetsSchema.VendorExtensible.Extensions).To(o.HaveKey("x-kubernetes-list-map-keys"))
+		o.Expect(widgetsSchema.Items).NotTo(o.BeNil())
+		o.Expect(widgetsSchema.Items.Schema).NotTo(o.BeNil())
+		o.Expect(widgetsSchema.Items.Schema.VendorExtensible.Extensions).To(o.HaveKey("x-kubernetes-preserve-unknown-fields"))
+
+		listOp := findListOperation(specsByVersion["v1beta1"], crd.Spec.Names.Plural)
+		o.Expect(listOp).NotTo(o.BeNil())
+		var fieldSelectorParam bool
+		for _, p := range listOp.Parameters {
+			if p.Name == "fieldSelector" {
+				fieldSelectorParam = true
+			}
+		}
+		o.Expect(fieldSelectorParam).To(o.BeTrue(), "list operation must expose a fieldSelector parameter for selectableFields")
+
+		specMarshalled, err := json.Marshal(specsByVersion["v1beta1"])
+		o.Expect(err).NotTo(o.HaveOccurred())
+		var roundTripped spec3.OpenAPI
+		o.Expect(json.Unmarshal(specMarshalled, &roundTripped)).To(o.Succeed())
+		o.Expect(findSchemaForKind(&roundTripped, crd.Spec.Names.Kind).Properties["spec"].VendorExtensible.Extensions).To(o.HaveKey("x-kubernetes-validations"))
+	})
+})
+
+// newPathPrefixingProxy returns a reverse proxy that forwards requests under prefix to the
+// apiserver identified by config, stripping the prefix before forwarding, mirroring the shape
+// of OpenShift's path-prefixed cluster proxies used for workspace-scoped API access. The
+// backend leg is authenticated with config's own TLS and credential material, so the proxy can
+// reach a real, secured apiserver rather than only a plaintext test server.
+func newPathPrefixingProxy(config *rest.Config, prefix string) (http.Handler, error) {
+	targetURL, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(targetURL)
+	proxy.Transport = transport
+	originalDirector := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		req.URL.Path = strings.TrimPrefix(req.URL.Path, prefix)
+		originalDirector(req)
+	}
+	return proxy, nil
+}
+
+// getOpenShiftAPIServerReplicas returns the current replica count of the openshift-apiserver
+// deployment, so callers that scale it down for a test can restore the cluster's actual prior
+// state rather than assuming a fixed replica count.
+func getOpenShiftAPIServerReplicas(oc *exutil.CLI) int {
+	out, err := oc.AsAdmin().Run("get").Args("deployment/apiserver", "-n", "openshift-apiserver", "-o", "jsonpath={.spec.replicas}").Output()
+	o.Expect(err).NotTo(o.HaveOccurred())
+	replicas, err := strconv.Atoi(strings.TrimSpace(out))
+	o.Expect(err).NotTo(o.HaveOccurred())
+	return replicas
+}
+
+func scaleOpenShiftAPIServer(oc *exutil.CLI, replicas int) {
+	_, err := oc.AsAdmin().Run("scale").Args("deployment/apiserver", "-n", "openshift-apiserver", fmt.Sprintf("--replicas=%d", replicas)).Output()
+	o.Expect(err).NotTo(o.HaveOccurred())
+}
+
+// newStructuralSchemaVersion builds a served, structural CRD version whose schema exercises
+// selectableFields, CEL validation rules, a map-type list, and preserve-unknown-fields. When
+// richSchema is false a minimal schema is returned, so callers can assert that per-version
+// schemas published in OpenAPI V3 are distinct.
+func newStructuralSchemaVersion(name string, richSchema bool) apiextensionsv1.CustomResourceDefinitionVersion {
+	props := map[string]apiextensionsv1.JSONSchemaProps{
+		"size": {Type: "string"},
+	}
+	version := apiextensionsv1.CustomResourceDefinitionVersion{
+		Name:    name,
+		Served:  true,
+		Storage: name == "v1beta1",
+		Schema: &apiextensionsv1.CustomResourceValidation{
+			OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+				Type:       "object",
+				Properties: props,
+			},
+		},
+	}
+	if !richSchema {
+		return version
+	}
+
+	version.SelectableFields = []apiextensionsv1.SelectableField{{JSONPath: ".spec.size"}}
+	version.Schema.OpenAPIV3Schema.Properties["spec"] = apiextensionsv1.JSONSchemaProps{
+		Type: "object",
+		XValidations: []apiextensionsv1.ValidationRule{
+			{Rule: "self.size != ''", Message: "size must not be empty"},
+		},
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"size": {Type: "string"},
+			"widgets": {
+				Type:         "array",
+				XListType:    pointerTo("map"),
+				XListMapKeys: []string{"name"},
+				Items: &apiextensionsv1.JSONSchemaPropsOrArray{
+					Schema: &apiextensionsv1.JSONSchemaProps{
+						Type: "object",
+						Properties: map[string]apiextensionsv1.JSONSchemaProps{
+							"name": {Type: "string"},
+						},
+						Required:               []string{"name"},
+						XPreserveUnknownFields: pointerTo(true),
+					},
+				},
+			},
+		},
+	}
+	return version
+}
+
+func pointerTo[T any](v T) *T {
+	return &v
+}
+
+// findSchemaForKind returns the component schema for kind from an OpenAPI V3 document, or nil
+// if no schema matches.
+func findSchemaForKind(openAPISpec *spec3.OpenAPI, kind string) *validationspec.Schema {
+	if openAPISpec == nil || openAPISpec.Components == nil {
+		return nil
+	}
+	for name, schema := range openAPISpec.Components.Schemas {
+		if strings.HasSuffix(name, "."+kind) || name == kind {
+			return schema
+		}
+	}
+	return nil
+}
+
+// findListOperation returns the GET operation for the list endpoint of the given plural
+// resource name, or nil if the path is not present in spec.
+func findListOperation(openAPISpec *spec3.OpenAPI, plural string) *spec3.Operation {
+	if openAPISpec == nil || openAPISpec.Paths == nil {
+		return nil
+	}
+	for path, item := range openAPISpec.Paths.Paths {
+		if strings.HasSuffix(path, "/"+plural) && item.Get != nil {
+			return item.Get
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package apimachinery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	g "github.com/onsi/ginkgo/v2"
+	o "github.com/onsi/gomega"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apiextensions-apiserver/test/integration/fixtures"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/openapi3"
+	"k8s.io/client-go/rest"
+	admissionapi "k8s.io/pod-security-admission/api"
+
+	"k8s.io/kubernetes/test/e2e/framework"
+
+	exutil "github.com/openshift/origin/test/extended/util"
+)
+
+// openAPIV3StressCRDCount is the default number of CRDs created to exercise OpenAPI V3
+// aggregation under load. Kept modest so the test remains usable in CI; override by editing
+// this constant when running the stress suite locally with a larger surface area.
+const openAPIV3StressCRDCount = 50
+
+// openAPIV3StressDeadline bounds how long a newly created group version may take to appear in
+// OpenAPI V3 discovery before the test considers aggregation staleness a failure.
+const openAPIV3StressDeadline = 2 * time.Minute
+
+var _ = g.Describe("[sig-api-machinery] OpenAPI V3 [Feature:OpenAPIV3Stress] [Slow]", func() {
+	defer g.GinkgoRecover()
+	oc := exutil.NewCLIWithPodSecurityLevel("openapi-v3-stress", admissionapi.LevelBaseline)
+
+	// Release : 4.19
+	// Testname: OpenAPI V3 aggregation under many CRDs
+	// Description: Creating a large number of CRDs across distinct groups MUST result in every
+	// new group version becoming visible in OpenAPI V3 discovery within a bounded deadline, and
+	// repeated requests for an unchanged OpenAPI V3 document MUST be served from the
+	// ETag/If-None-Match cache rather than recomputing the merge every time.
+	g.It(fmt.Sprintf("should aggregate OpenAPI V3 for %d CRDs within a bounded deadline and honor ETags", openAPIV3StressCRDCount), func(ctx context.Context) {
+		config, err := framework.LoadConfig()
+		o.Expect(err).NotTo(o.HaveOccurred())
+		apiExtensionClient, err := apiextensionclientset.NewForConfig(config)
+		o.Expect(err).NotTo(o.HaveOccurred())
+		dynamicClient, err := dynamic.NewForConfig(config)
+		o.Expect(err).NotTo(o.HaveOccurred())
+
+		gvs := make([]schema.GroupVersion, 0, openAPIV3StressCRDCount)
+		for i := 0; i < openAPIV3StressCRDCount; i++ {
+			crd := fixtures.NewRandomNameV1CustomResourceDefinition(apiextensionsv1.ClusterScoped)
+			crd.Spec.Group = fmt.Sprintf("stress-%d.openapiv3.openshift.io", i)
+			crd.Name = crd.Spec.Names.Plural + "." + crd.Spec.Group
+
+			_, err := fixtures.CreateNewV1CustomResourceDefinition(crd, apiExtensionClient, dynamicClient)
+			o.Expect(err).NotTo(o.HaveOccurred())
+			defer func(crd *apiextensionsv1.CustomResourceDefinition) {
+				o.Expect(fixtures.DeleteV1CustomResourceDefinition(crd, apiExtensionClient)).To(o.Succeed())
+			}(crd)
+
+			gvs = append(gvs, schema.GroupVersion{Group: crd.Spec.Group, Version: crd.Spec.Versions[0].Name})
+		}
+
+		c := openapi3.NewRoot(oc.AdminKubeClient().Discovery().OpenAPIV3())
+
+		start := time.Now()
+		var latencies []time.Duration
+		var totalBytes int
+		for _, gv := range gvs {
+			var latency time.Duration
+			err := wait.PollImmediate(time.Second, openAPIV3StressDeadline, func() (bool, error) {
+				attemptStart := time.Now()
+				spec, err := c.GVSpec(gv)
+				if err != nil {
+					return false, nil
+				}
+				latency = time.Since(attemptStart)
+				marshalled, err := json.Marshal(spec)
+				o.Expect(err).NotTo(o.HaveOccurred())
+				totalBytes += len(marshalled)
+				return true, nil
+			})
+			o.Expect(err).NotTo(o.HaveOccurred(), "group version %s did not appear in OpenAPI V3 discovery within %s", gv, openAPIV3StressDeadline)
+			latencies = append(latencies, latency)
+		}
+		g.By(fmt.Sprintf("observed all %d group versions within %s (p50=%s p95=%s, total=%d bytes)",
+			len(gvs), time.Since(start), percentile(latencies, 0.50), percentile(latencies, 0.95), totalBytes))
+
+		g.By("verifying repeated requests for an unchanged OpenAPI V3 document hit the ETag cache")
+		assertGVSpecIsCached(config, gvs[0])
+	})
+})
+
+// assertGVSpecIsCached drops to a raw HTTP client built from config (bypassing openapi3.Root's own caching) to
+// confirm the server itself honors If-None-Match for an unchanged OpenAPI V3 document by
+// replying 304 Not Modified.
+func assertGVSpecIsCached(config *rest.Config, gv schema.GroupVersion) {
+	httpClient, err := rest.HTTPClientFor(config)
+	o.Expect(err).NotTo(o.HaveOccurred())
+
+	path := fmt.Sprintf("%s/openapi/v3/apis/%s/%s", config.Host, gv.Group, gv.Version)
+	req, err := http.NewRequest(http.MethodGet, path, nil)
+	o.Expect(err).NotTo(o.HaveOccurred())
+	resp, err := httpClient.Do(req)
+	o.Expect(err).NotTo(o.HaveOccurred())
+	resp.Body.Close()
+	o.Expect(resp.StatusCode).To(o.Equal(http.StatusOK))
+
+	etag := resp.Header.Get("ETag")
+	o.Expect(etag).NotTo(o.BeEmpty(), "expected the OpenAPI V3 endpoint to set an ETag")
+
+	cachedReq, err := http.NewRequest(http.MethodGet, path, nil)
+	o.Expect(err).NotTo(o.HaveOccurred())
+	cachedReq.Header.Set("If-None-Match", etag)
+	cachedResp, err := httpClient.Do(cachedReq)
+	o.Expect(err).NotTo(o.HaveOccurred())
+	cachedResp.Body.Close()
+	o.Expect(cachedResp.StatusCode).To(o.Equal(http.StatusNotModified), "expected a cached 304 response when re-requesting an unchanged OpenAPI V3 document")
+}
+
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[idx]
+}